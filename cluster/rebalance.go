@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	dqliteClient "github.com/canonical/go-dqlite/client"
+)
+
+// RolesPolicy is the target shape of the dqlite cluster that Rebalance converges towards.
+// Any member that is neither a Voter nor a StandBy under the policy remains a Spare.
+type RolesPolicy struct {
+	Voters   int
+	StandBys int
+}
+
+// DefaultRolesPolicy is applied by a State whose RolesPolicy field is left at the zero value.
+var DefaultRolesPolicy = RolesPolicy{Voters: 3, StandBys: 2}
+
+// staleHeartbeat is how long a member may go without a heartbeat before Rebalance treats it as offline.
+const staleHeartbeat = 20 * time.Second
+
+// RebalanceMember is a dqlite node annotated with the liveness information Rebalance needs to plan role changes.
+type RebalanceMember struct {
+	dqliteClient.NodeInfo
+
+	Online    bool
+	Heartbeat time.Time
+}
+
+// RoleAssignment is a single leader.Assign call that a RebalancePlan wants applied.
+type RoleAssignment struct {
+	ID   uint64
+	Role dqliteClient.NodeRole
+}
+
+// RebalancePlan is the set of role changes needed to converge the cluster towards a RolesPolicy.
+type RebalancePlan struct {
+	// Transfer is the node ID that leadership must be handed to before Assignments are applied, or 0 if no
+	// leadership transfer is required.
+	Transfer uint64
+
+	Assignments []RoleAssignment
+}
+
+// IsEmpty returns true if applying the plan would not change any member's role.
+func (p RebalancePlan) IsEmpty() bool {
+	return p.Transfer == 0 && len(p.Assignments) == 0
+}
+
+// Rebalance computes the role changes needed to converge the cluster towards policy.
+//
+// It classifies members as healthy (online and recently heartbeating) or not, then:
+//   - promotes the lowest-ID (oldest-joined) healthy standby, or failing that spare, while there are fewer
+//     voters than policy.Voters;
+//   - demotes the highest-ID voter to standby while there are more voters than policy.Voters, transferring
+//     leadership away first if the demoted voter is the current leader;
+//   - repeats the same promotion/demotion steps between standbys and spares.
+//
+// Stale or offline members are left untouched; Rebalance never assigns a role to a node it cannot
+// currently reach, since leader.Assign against an unreachable node blocks on a dqlite round trip. A member
+// whose Heartbeat is the zero value hasn't had the chance to record one yet, e.g. it joined moments ago and
+// its own heartbeat loop hasn't ticked; it counts as healthy on Online alone rather than as stale, so the
+// immediate post-join nudge can actually promote it instead of waiting for the next periodic run.
+func Rebalance(leaderID uint64, members []RebalanceMember, policy RolesPolicy) RebalancePlan {
+	healthy := make([]RebalanceMember, 0, len(members))
+	for _, m := range members {
+		if m.Online && (m.Heartbeat.IsZero() || time.Since(m.Heartbeat) < staleHeartbeat) {
+			healthy = append(healthy, m)
+		}
+	}
+
+	sort.Slice(healthy, func(i, j int) bool { return healthy[i].ID < healthy[j].ID })
+
+	byRole := func(role dqliteClient.NodeRole) []RebalanceMember {
+		var out []RebalanceMember
+		for _, m := range healthy {
+			if m.Role == role {
+				out = append(out, m)
+			}
+		}
+
+		return out
+	}
+
+	var plan RebalancePlan
+	voters := byRole(dqliteClient.Voter)
+	standbys := byRole(dqliteClient.StandBy)
+	spares := byRole(dqliteClient.Spare)
+
+	promote := func(role dqliteClient.NodeRole, pool *[]RebalanceMember) (RebalanceMember, bool) {
+		if len(*pool) == 0 {
+			return RebalanceMember{}, false
+		}
+
+		candidate := (*pool)[0]
+		*pool = (*pool)[1:]
+		plan.Assignments = append(plan.Assignments, RoleAssignment{ID: candidate.ID, Role: role})
+
+		return candidate, true
+	}
+
+	demote := func(role dqliteClient.NodeRole, pool *[]RebalanceMember) (RebalanceMember, bool) {
+		if len(*pool) == 0 {
+			return RebalanceMember{}, false
+		}
+
+		victim := (*pool)[len(*pool)-1]
+		*pool = (*pool)[:len(*pool)-1]
+
+		if victim.ID == leaderID {
+			for _, m := range voters {
+				if m.ID != leaderID {
+					plan.Transfer = m.ID
+					break
+				}
+			}
+		}
+
+		plan.Assignments = append(plan.Assignments, RoleAssignment{ID: victim.ID, Role: role})
+
+		return victim, true
+	}
+
+	for len(voters) < policy.Voters {
+		candidate, ok := promote(dqliteClient.Voter, &standbys)
+		if !ok {
+			candidate, ok = promote(dqliteClient.Voter, &spares)
+			if !ok {
+				break
+			}
+		}
+
+		voters = append(voters, candidate)
+	}
+
+	for len(voters) > policy.Voters {
+		victim, ok := demote(dqliteClient.StandBy, &voters)
+		if !ok {
+			break
+		}
+
+		standbys = append(standbys, victim)
+	}
+
+	for len(standbys) < policy.StandBys {
+		candidate, ok := promote(dqliteClient.StandBy, &spares)
+		if !ok {
+			break
+		}
+
+		standbys = append(standbys, candidate)
+	}
+
+	for len(standbys) > policy.StandBys {
+		_, ok := demote(dqliteClient.Spare, &standbys)
+		if !ok {
+			break
+		}
+	}
+
+	return plan
+}
+
+// Apply issues the Transfer and Assign calls described by plan against the dqlite leader.
+func Apply(ctx context.Context, leader *dqliteClient.Client, plan RebalancePlan) error {
+	if plan.Transfer != 0 {
+		err := leader.Transfer(ctx, plan.Transfer)
+		if err != nil {
+			return fmt.Errorf("Failed to transfer dqlite leadership before rebalancing: %w", err)
+		}
+	}
+
+	for _, assignment := range plan.Assignments {
+		err := leader.Assign(ctx, assignment.ID, assignment.Role)
+		if err != nil {
+			return fmt.Errorf("Failed to assign role %v to dqlite node %d: %w", assignment.Role, assignment.ID, err)
+		}
+	}
+
+	return nil
+}
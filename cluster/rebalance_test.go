@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	dqliteClient "github.com/canonical/go-dqlite/client"
+)
+
+func member(id uint64, role dqliteClient.NodeRole, online bool, age time.Duration) RebalanceMember {
+	return RebalanceMember{
+		NodeInfo:  dqliteClient.NodeInfo{ID: id, Role: role},
+		Online:    online,
+		Heartbeat: time.Now().Add(-age),
+	}
+}
+
+func TestRebalancePromotesLowestIDStandby(t *testing.T) {
+	members := []RebalanceMember{
+		member(1, dqliteClient.Voter, true, 0),
+		member(2, dqliteClient.Voter, true, 0),
+		member(3, dqliteClient.StandBy, true, 0),
+		member(4, dqliteClient.StandBy, true, 0),
+	}
+
+	plan := Rebalance(1, members, RolesPolicy{Voters: 3, StandBys: 1})
+
+	if len(plan.Assignments) != 1 {
+		t.Fatalf("expected 1 assignment, got %d: %+v", len(plan.Assignments), plan.Assignments)
+	}
+
+	got := plan.Assignments[0]
+	if got.ID != 3 || got.Role != dqliteClient.Voter {
+		t.Fatalf("expected node 3 promoted to voter, got %+v", got)
+	}
+
+	if plan.Transfer != 0 {
+		t.Fatalf("expected no leadership transfer, got %d", plan.Transfer)
+	}
+}
+
+func TestRebalanceDemotesHighestIDVoterAndTransfersLeadership(t *testing.T) {
+	members := []RebalanceMember{
+		member(1, dqliteClient.Voter, true, 0),
+		member(2, dqliteClient.Voter, true, 0),
+		member(3, dqliteClient.Voter, true, 0),
+	}
+
+	// The leader (node 3) is the highest-ID voter, and so is the one demote() would pick: it must be
+	// transferred away from before being demoted.
+	plan := Rebalance(3, members, RolesPolicy{Voters: 2, StandBys: 1})
+
+	if plan.Transfer == 0 {
+		t.Fatalf("expected a leadership transfer away from the demoted leader, got none")
+	}
+
+	if plan.Transfer == 3 {
+		t.Fatalf("must not transfer leadership to the node being demoted")
+	}
+
+	found := false
+	for _, a := range plan.Assignments {
+		if a.ID == 3 {
+			found = true
+			if a.Role != dqliteClient.StandBy {
+				t.Fatalf("expected node 3 demoted to standby, got %v", a.Role)
+			}
+		}
+	}
+
+	if !found {
+		t.Fatalf("expected an assignment demoting node 3, got %+v", plan.Assignments)
+	}
+}
+
+func TestRebalanceIgnoresStaleAndOfflineMembers(t *testing.T) {
+	members := []RebalanceMember{
+		member(1, dqliteClient.Voter, true, 0),
+		member(2, dqliteClient.StandBy, true, 0),
+		member(3, dqliteClient.StandBy, false, 0),          // offline
+		member(4, dqliteClient.StandBy, true, time.Minute), // stale heartbeat
+	}
+
+	plan := Rebalance(1, members, RolesPolicy{Voters: 3, StandBys: 2})
+
+	for _, a := range plan.Assignments {
+		if a.ID == 3 || a.ID == 4 {
+			t.Fatalf("expected unhealthy node %d to be left untouched, got assignment %+v", a.ID, a)
+		}
+	}
+
+	// Only node 2 is both healthy and a standby, so it's the only candidate available to promote.
+	if len(plan.Assignments) != 1 || plan.Assignments[0].ID != 2 {
+		t.Fatalf("expected only node 2 promoted, got %+v", plan.Assignments)
+	}
+}
+
+func TestRebalancePromotesFreshlyJoinedMemberWithZeroHeartbeat(t *testing.T) {
+	members := []RebalanceMember{
+		member(1, dqliteClient.Voter, true, 0),
+		member(2, dqliteClient.Voter, true, 0),
+		{NodeInfo: dqliteClient.NodeInfo{ID: 3, Role: dqliteClient.StandBy}, Online: true}, // zero-value Heartbeat
+	}
+
+	// Node 3 just joined and hasn't recorded a heartbeat yet; it must still count as healthy so the
+	// immediate post-join nudge can promote it instead of waiting for its first heartbeat tick.
+	plan := Rebalance(1, members, RolesPolicy{Voters: 3, StandBys: 0})
+
+	if len(plan.Assignments) != 1 || plan.Assignments[0].ID != 3 || plan.Assignments[0].Role != dqliteClient.Voter {
+		t.Fatalf("expected node 3 promoted to voter despite a zero-value heartbeat, got %+v", plan.Assignments)
+	}
+}
+
+func TestRebalanceEmptyPlanWhenAlreadyConverged(t *testing.T) {
+	members := []RebalanceMember{
+		member(1, dqliteClient.Voter, true, 0),
+		member(2, dqliteClient.Voter, true, 0),
+		member(3, dqliteClient.Voter, true, 0),
+		member(4, dqliteClient.StandBy, true, 0),
+		member(5, dqliteClient.StandBy, true, 0),
+	}
+
+	plan := Rebalance(1, members, DefaultRolesPolicy)
+
+	if !plan.IsEmpty() {
+		t.Fatalf("expected an empty plan, got %+v", plan)
+	}
+}
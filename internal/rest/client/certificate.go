@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/canonical/microcluster/rest/types"
+)
+
+// clusterCertificateRequest is the body SendClusterCertificate pushes to a member during phase 1 of a
+// cluster certificate rotation.
+type clusterCertificateRequest struct {
+	ClusterCert types.X509Certificate `json:"cluster_cert"`
+	ClusterKey  string                `json:"cluster_key"`
+}
+
+// SendClusterCertificate stages a new cluster CA certificate and key on this member, writing them next to
+// the active pair without switching to them yet. Staging does not load the new certificate: the member's
+// in-memory certificates stay as they are until ActivateClusterCertificate.
+func (c *Client) SendClusterCertificate(ctx context.Context, cert types.X509Certificate, key string) error {
+	req := clusterCertificateRequest{ClusterCert: cert, ClusterKey: key}
+
+	return c.queryStruct(ctx, http.MethodPost, "/1.0/cluster/certificate/stage", req, nil)
+}
+
+// ActivateClusterCertificate swaps this member's active cluster certificate to the one previously staged
+// with SendClusterCertificate.
+func (c *Client) ActivateClusterCertificate(ctx context.Context) error {
+	return c.queryStruct(ctx, http.MethodPost, "/1.0/cluster/certificate/activate", nil, nil)
+}
+
+// RotateClusterCertificate asks the cluster leader to rotate the cluster CA certificate. Non-leader members
+// forward PUT /cluster/certificate requests here.
+func (c *Client) RotateClusterCertificate(ctx context.Context) error {
+	return c.queryStruct(ctx, http.MethodPut, "/1.0/cluster/certificate", nil, nil)
+}
+
+// RollbackClusterCertificate discards a cluster CA keypair previously staged with SendClusterCertificate,
+// undoing phase 1 of a rotation that failed before every member acknowledged it.
+func (c *Client) RollbackClusterCertificate(ctx context.Context) error {
+	return c.queryStruct(ctx, http.MethodDelete, "/1.0/cluster/certificate/stage", nil, nil)
+}
+
+// RevertClusterCertificate undoes a previous ActivateClusterCertificate, swapping this member's active
+// cluster certificate back to the one it had before, and re-staging the certificate it had activated so a
+// retried rotation can activate it again instead of starting over from phase 1.
+func (c *Client) RevertClusterCertificate(ctx context.Context) error {
+	return c.queryStruct(ctx, http.MethodDelete, "/1.0/cluster/certificate/activate", nil, nil)
+}
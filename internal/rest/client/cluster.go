@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	internalTypes "github.com/canonical/microcluster/internal/rest/types"
+)
+
+// AddClusterMember asks the cluster leader to add req as a new cluster member and returns the join token
+// and cluster certificate the new member needs. This runs synchronously rather than through an Operation:
+// the caller is still untrusted at this point, so it couldn't poll GET /1.0/operations/{uuid} to learn the
+// outcome of a backgrounded join anyway. A PreJoin hook veto surfaces here as the returned error, verbatim.
+func (c *Client) AddClusterMember(ctx context.Context, req internalTypes.ClusterMember) (*internalTypes.TokenResponse, error) {
+	tokenResponse := internalTypes.TokenResponse{}
+	err := c.queryStruct(ctx, http.MethodPost, "/1.0/cluster", req, &tokenResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenResponse, nil
+}
+
+// DeleteClusterMember requests that the cluster member called name be removed. Removal can run long enough
+// to blow past a flat deadline — forwarding to the leader, the dqlite leader.Remove call, and the reset RPC
+// against the removed member are all genuinely unbounded — so the request returns as soon as it's accepted
+// and this waits on the resulting Operation instead. A PreRemove hook veto surfaces here as the returned
+// error, verbatim, rather than needing to be polled for separately. If force is true, the removal tolerates
+// the target being unreachable: the leader skips the reset RPC against it and does not abort if it can't be
+// cleanly removed from dqlite.
+func (c *Client) DeleteClusterMember(ctx context.Context, name string, force bool) error {
+	query := url.Values{}
+	if force {
+		query.Set("force", "1")
+	}
+
+	path := fmt.Sprintf("/1.0/cluster/%s", name)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	op := Operation{client: c}
+	err := c.queryStruct(ctx, http.MethodDelete, path, nil, &op)
+	if err != nil {
+		return err
+	}
+
+	op.client = c
+
+	return waitIgnoringReExec(ctx, &op)
+}
+
+// UpdateClusterMembers replaces this member's local trust store with members, e.g. after another member is
+// removed from the cluster so the local record reflects it without waiting to learn about the change some
+// other way.
+func (c *Client) UpdateClusterMembers(ctx context.Context, members []internalTypes.ClusterMember) error {
+	return c.queryStruct(ctx, http.MethodPut, "/1.0/cluster/members", members, nil)
+}
+
+// ResetClusterMember tells the cluster member called name to wipe its local state and re-exec itself, and
+// waits for the resulting Operation to finish.
+func (c *Client) ResetClusterMember(ctx context.Context, name string) error {
+	op := Operation{client: c}
+	err := c.queryStruct(ctx, http.MethodPut, fmt.Sprintf("/1.0/cluster/%s", name), nil, &op)
+	if err != nil {
+		return err
+	}
+
+	op.client = c
+
+	return waitIgnoringReExec(ctx, &op)
+}
+
+// waitIgnoringReExec waits for op to finish, treating "No operation exists" as success rather than a
+// failure: the daemon on the other end may have replaced its own process to complete op, wiping its
+// in-memory operations along with it, and the fresh process no longer recognizing op once polled is the
+// expected shape of that, not a failure.
+func waitIgnoringReExec(ctx context.Context, op *Operation) error {
+	err := op.Wait(ctx, nil)
+	if err != nil && !strings.Contains(err.Error(), "No operation exists") {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OperationStatus mirrors the lifecycle stage the server reports for a long-running operation.
+type OperationStatus string
+
+const (
+	// OperationRunning is set while the operation's function is still executing.
+	OperationRunning OperationStatus = "Running"
+
+	// OperationSuccess is set once the operation's function has returned without error.
+	OperationSuccess OperationStatus = "Success"
+
+	// OperationFailure is set once the operation's function has returned an error.
+	OperationFailure OperationStatus = "Failure"
+
+	// OperationCancelled is set when the operation was deleted before its function returned.
+	OperationCancelled OperationStatus = "Cancelled"
+)
+
+// Operation is the client-side view of a server-tracked long-running cluster mutation (join, leave,
+// re-exec): the body of the initial 202 Accepted response, and of every subsequent poll of its Location.
+type Operation struct {
+	ID        string
+	Status    OperationStatus
+	Step      string
+	Err       string          `json:"Err,omitempty"`
+	Result    json.RawMessage `json:"Result,omitempty"`
+	CreatedAt time.Time
+
+	client *Client
+}
+
+// operationPollInterval is how often Wait re-polls an operation that is still running.
+const operationPollInterval = 500 * time.Millisecond
+
+// Wait blocks until the operation reaches a terminal status, or ctx is done, whichever happens first. On
+// success, its Result is decoded into out (if non-nil); on failure, Err is returned verbatim as the error
+// instead of the opaque request timeout the caller would otherwise see.
+func (o *Operation) Wait(ctx context.Context, out any) error {
+	ticker := time.NewTicker(operationPollInterval)
+	defer ticker.Stop()
+
+	op := o
+	for {
+		switch op.Status {
+		case OperationSuccess:
+			if out == nil || len(op.Result) == 0 {
+				return nil
+			}
+
+			return json.Unmarshal(op.Result, out)
+		case OperationFailure:
+			return errors.New(op.Err)
+		case OperationCancelled:
+			return fmt.Errorf("Operation was cancelled")
+		}
+
+		select {
+		case <-ticker.C:
+			next := Operation{}
+			err := op.client.queryStruct(ctx, http.MethodGet, fmt.Sprintf("/1.0/operations/%s", op.ID), nil, &next)
+			if err != nil {
+				return err
+			}
+
+			next.client = op.client
+			op = &next
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
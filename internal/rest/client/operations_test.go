@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOperationWaitDecodesResultOnSuccess(t *testing.T) {
+	type payload struct {
+		Name string `json:"Name"`
+	}
+
+	op := &Operation{
+		Status: OperationSuccess,
+		Result: json.RawMessage(`{"Name":"new-member"}`),
+	}
+
+	var got payload
+	err := op.Wait(context.Background(), &got)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	if got.Name != "new-member" {
+		t.Fatalf("expected decoded Name %q, got %q", "new-member", got.Name)
+	}
+}
+
+func TestOperationWaitSucceedsWithNilOutAndNoResult(t *testing.T) {
+	op := &Operation{Status: OperationSuccess}
+
+	err := op.Wait(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+}
+
+func TestOperationWaitReturnsOperationErrOnFailure(t *testing.T) {
+	op := &Operation{Status: OperationFailure, Err: "node holds 1 unreplicated OSD"}
+
+	err := op.Wait(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "node holds 1 unreplicated OSD") {
+		t.Fatalf("expected the operation's Err surfaced, got %v", err)
+	}
+}
+
+func TestOperationWaitReturnsErrorOnCancelled(t *testing.T) {
+	op := &Operation{Status: OperationCancelled}
+
+	err := op.Wait(context.Background(), nil)
+	if err == nil {
+		t.Fatalf("expected an error for a cancelled operation")
+	}
+}
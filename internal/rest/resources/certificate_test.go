@@ -0,0 +1,103 @@
+package resources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/microcluster/internal/state"
+	"github.com/canonical/microcluster/internal/sys"
+)
+
+func TestStageActivateClusterCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	reloaded := false
+	st := &state.State{
+		Context: context.Background(),
+		OS:      &sys.OS{StateDir: dir},
+		ReloadClusterCert: func() error {
+			reloaded = true
+			return nil
+		},
+	}
+
+	err := os.WriteFile(filepath.Join(dir, "cluster.crt"), []byte("old-cert"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to seed the active certificate: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "cluster.key"), []byte("old-key"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to seed the active key: %v", err)
+	}
+
+	err = stageClusterCertificate(st, []byte("new-cert"), []byte("new-key"))
+	if err != nil {
+		t.Fatalf("stageClusterCertificate: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "cluster.crt.new")); err != nil {
+		t.Fatalf("expected the staged certificate to exist: %v", err)
+	}
+
+	err = activateClusterCertificate(st)
+	if err != nil {
+		t.Fatalf("activateClusterCertificate: %v", err)
+	}
+
+	if !reloaded {
+		t.Fatalf("expected ReloadClusterCert to be called after activation")
+	}
+
+	active, err := os.ReadFile(filepath.Join(dir, "cluster.crt"))
+	if err != nil || string(active) != "new-cert" {
+		t.Fatalf("expected the staged certificate to become active, got %q, err %v", active, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "cluster.crt.old")); err != nil {
+		t.Fatalf("expected the previous certificate to be retained as .old: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "cluster.crt.new")); !os.IsNotExist(err) {
+		t.Fatalf("expected the staged certificate path to be consumed by activation, stat err %v", err)
+	}
+}
+
+func TestRollbackClusterCertificateRemovesStagedFilesOnly(t *testing.T) {
+	dir := t.TempDir()
+	st := &state.State{Context: context.Background(), OS: &sys.OS{StateDir: dir}}
+
+	err := os.WriteFile(filepath.Join(dir, "cluster.crt.new"), []byte("staged-cert"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to seed the staged certificate: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "cluster.key.new"), []byte("staged-key"), 0o600)
+	if err != nil {
+		t.Fatalf("failed to seed the staged key: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "cluster.crt"), []byte("active-cert"), 0o644)
+	if err != nil {
+		t.Fatalf("failed to seed the active certificate: %v", err)
+	}
+
+	// acked is nil: rollbackClusterCertificate must not need to contact any member to undo a purely local
+	// stage that hasn't been pushed out yet.
+	rollbackClusterCertificate(st, nil)
+
+	if _, err := os.Stat(filepath.Join(dir, "cluster.crt.new")); !os.IsNotExist(err) {
+		t.Fatalf("expected the staged certificate to be removed, stat err %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "cluster.key.new")); !os.IsNotExist(err) {
+		t.Fatalf("expected the staged key to be removed, stat err %v", err)
+	}
+
+	active, err := os.ReadFile(filepath.Join(dir, "cluster.crt"))
+	if err != nil || string(active) != "active-cert" {
+		t.Fatalf("expected the active certificate to be left untouched, got %q, err %v", active, err)
+	}
+}
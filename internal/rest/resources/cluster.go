@@ -2,11 +2,15 @@ package resources
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +18,7 @@ import (
 	dqliteClient "github.com/canonical/go-dqlite/client"
 	"github.com/gorilla/mux"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
 	"golang.org/x/sys/unix"
@@ -44,6 +49,59 @@ var clusterMemberCmd = rest.Endpoint{
 	Delete: rest.EndpointAction{Handler: clusterMemberDelete, AccessHandler: access.AllowAuthenticated},
 }
 
+var clusterMembersCmd = rest.Endpoint{
+	Path: "cluster/members",
+
+	Put: rest.EndpointAction{Handler: clusterMembersPut, AccessHandler: access.AllowAuthenticated},
+}
+
+var clusterRebalanceCmd = rest.Endpoint{
+	Path: "cluster/rebalance",
+
+	Get:  rest.EndpointAction{Handler: clusterRebalanceGet, AccessHandler: access.AllowAuthenticated},
+	Post: rest.EndpointAction{Handler: clusterRebalancePost, AccessHandler: access.AllowAuthenticated},
+}
+
+var clusterCertificateCmd = rest.Endpoint{
+	Path: "cluster/certificate",
+
+	Put: rest.EndpointAction{Handler: clusterCertificatePut, AccessHandler: access.AllowAuthenticated},
+}
+
+var clusterCertificateStageCmd = rest.Endpoint{
+	Path: "cluster/certificate/stage",
+
+	Post:   rest.EndpointAction{Handler: clusterCertificateStagePost, AccessHandler: access.AllowAuthenticated},
+	Delete: rest.EndpointAction{Handler: clusterCertificateStageDelete, AccessHandler: access.AllowAuthenticated},
+}
+
+var clusterCertificateActivateCmd = rest.Endpoint{
+	Path: "cluster/certificate/activate",
+
+	Post:   rest.EndpointAction{Handler: clusterCertificateActivatePost, AccessHandler: access.AllowAuthenticated},
+	Delete: rest.EndpointAction{Handler: clusterCertificateActivateDelete, AccessHandler: access.AllowAuthenticated},
+}
+
+// clusterCertificateGraceWindow is how long the retired cluster certificate is kept on disk after a
+// rotation completes, in case a member missed the swap RPC and needs to fall back to it briefly.
+const clusterCertificateGraceWindow = 24 * time.Hour
+
+// preJoinVetoError marks an error returned by a PreJoin hook, so clusterPost can tell a deliberate veto
+// apart from any other failure recording the new member and map it to a 400 rather than a 500.
+type preJoinVetoError struct {
+	err error
+}
+
+func (e *preJoinVetoError) Error() string { return e.err.Error() }
+func (e *preJoinVetoError) Unwrap() error { return e.err }
+
+// clusterPost adds a new member to the cluster. It stays synchronous, unlike clusterMemberPut and
+// clusterMemberDelete: the joining node calls this before it is a trusted cluster member, so it is rejected
+// by access.AllowAuthenticated and cannot poll GET /1.0/operations/{uuid} to learn the outcome of a
+// backgrounded join. Forwarding to the leader, a consumer's PreJoin hook, and the dqlite transaction that
+// records the member are therefore all run under a flat 30 second timeout rather than handed off to an
+// Operation; a join that blows past it should be retried rather than polled for. A PreJoin veto reaches
+// the caller as a 400 rather than the request's own 500, via preJoinVetoError.
 func clusterPost(state *state.State, r *http.Request) response.Response {
 	req := internalTypes.ClusterMember{}
 
@@ -53,24 +111,41 @@ func clusterPost(state *state.State, r *http.Request) response.Response {
 		return response.BadRequest(err)
 	}
 
-	// Set a 5 second timeout in case dqlite locks up.
-	ctx, cancel := context.WithTimeout(state.Context, time.Second*5)
+	// Check if any of the remote's addresses are currently in use.
+	existingRemote := state.Remotes().RemoteByAddress(req.Address)
+	if existingRemote != nil {
+		return response.SmartError(fmt.Errorf("Remote with address %q exists", req.Address.String()))
+	}
+
+	ctx, cancel := context.WithTimeout(state.Context, time.Second*30)
 	defer cancel()
 
-	leaderClient, err := state.Database.Leader(ctx)
+	tokenResponse, err := clusterPostRun(ctx, state, req)
 	if err != nil {
+		var vetoErr *preJoinVetoError
+		if errors.As(err, &vetoErr) {
+			return response.BadRequest(vetoErr)
+		}
+
 		return response.SmartError(err)
 	}
 
-	leaderInfo, err := leaderClient.Leader(ctx)
+	return response.SyncResponse(true, tokenResponse)
+}
+
+func clusterPostRun(ctx context.Context, state *state.State, req internalTypes.ClusterMember) (*internalTypes.TokenResponse, error) {
+	// Set a 5 second timeout in case dqlite locks up.
+	leaderCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	leaderClient, err := state.Database.Leader(leaderCtx)
 	if err != nil {
-		return response.SmartError(err)
+		return nil, err
 	}
 
-	// Check if any of the remote's addresses are currently in use.
-	existingRemote := state.Remotes().RemoteByAddress(req.Address)
-	if existingRemote != nil {
-		return response.SmartError(fmt.Errorf("Remote with address %q exists", req.Address.String()))
+	leaderInfo, err := leaderClient.Leader(leaderCtx)
+	if err != nil {
+		return nil, err
 	}
 
 	newRemote := trust.Remote{
@@ -79,28 +154,45 @@ func clusterPost(state *state.State, r *http.Request) response.Response {
 		Certificate: req.Certificate,
 	}
 
+	// The view of the new member handed to the PreJoin/PostJoin hooks: plain strings, defined in the public
+	// rest/types package, so a consumer module can write a hook without importing anything internal.
+	publicMember := types.ClusterMember{
+		Name:        req.Name,
+		Address:     req.Address.String(),
+		Certificate: req.Certificate.String(),
+	}
+
 	// Forward request to leader.
 	if leaderInfo.Address != state.Address.URL.Host {
-		client, err := state.Leader()
+		leader, err := state.Leader()
 		if err != nil {
-			return response.SmartError(err)
+			return nil, err
 		}
 
-		tokenResponse, err := client.AddClusterMember(state.Context, req)
+		tokenResponse, err := leader.AddClusterMember(ctx, req)
 		if err != nil {
-			return response.SmartError(err)
+			return nil, err
 		}
 
 		// If we are not the leader, just add the cluster member to our local store for authentication.
 		err = state.Remotes().Add(state.OS.TrustDir, newRemote)
 		if err != nil {
-			return response.SmartError(err)
+			return nil, err
 		}
 
-		return response.SyncResponse(true, tokenResponse)
+		return tokenResponse, nil
 	}
 
-	err = state.Database.Transaction(state.Context, func(ctx context.Context, tx *db.Tx) error {
+	err = state.Database.Transaction(ctx, func(ctx context.Context, tx *db.Tx) error {
+		// Give consumers (MicroCeph, MicroOVN, ...) a chance to veto the join or seed their own schema
+		// before the membership row exists, atomically with it since both happen in this transaction.
+		if state.Hooks.PreJoin != nil {
+			err := state.Hooks.PreJoin(ctx, tx.Tx, publicMember)
+			if err != nil {
+				return &preJoinVetoError{err: err}
+			}
+		}
+
 		dbClusterMember := cluster.InternalClusterMember{
 			Name:        req.Name,
 			Address:     req.Address.String(),
@@ -123,7 +215,7 @@ func clusterPost(state *state.State, r *http.Request) response.Response {
 		return cluster.DeleteInternalTokenRecord(ctx, tx, record.Name)
 	})
 	if err != nil {
-		return response.SmartError(err)
+		return nil, err
 	}
 
 	remotes := state.Remotes()
@@ -140,7 +232,7 @@ func clusterPost(state *state.State, r *http.Request) response.Response {
 
 	clusterCert, err := state.ClusterCert().PublicKeyX509()
 	if err != nil {
-		return response.SmartError(err)
+		return nil, err
 	}
 
 	tokenResponse := internalTypes.TokenResponse{
@@ -153,10 +245,27 @@ func clusterPost(state *state.State, r *http.Request) response.Response {
 	// Add the cluster member to our local store for authentication.
 	err = state.Remotes().Add(state.OS.TrustDir, newRemote)
 	if err != nil {
-		return response.SmartError(err)
+		return nil, err
 	}
 
-	return response.SyncResponse(true, tokenResponse)
+	// The new member joins as Pending, so nudge the cluster towards its target voter/standby shape now
+	// that there's a candidate to promote. Runs in the background since it isn't needed to answer the
+	// request.
+	triggerRebalance(state, leaderClient)
+
+	// The join has already been committed, so a PostJoin failure is logged rather than failing the
+	// request; it runs in the background against state.Context, since ctx is cancelled once clusterPost
+	// returns.
+	if state.Hooks.PostJoin != nil {
+		go func() {
+			err := state.Hooks.PostJoin(state.Context, publicMember)
+			if err != nil {
+				logger.Warnf("PostJoin hook failed for new cluster member %q: %v", req.Name, err)
+			}
+		}()
+	}
+
+	return &tokenResponse, nil
 }
 
 func clusterGet(state *state.State, r *http.Request) response.Response {
@@ -207,151 +316,607 @@ func clusterGet(state *state.State, r *http.Request) response.Response {
 	return response.SyncResponse(true, apiClusterMembers)
 }
 
+// clusterCertificatePut rotates the cluster CA certificate in three phases: first the leader generates a
+// new keypair and stages it on itself and every member, written next to the active pair without being
+// loaded; once every member has acknowledged it, a second round swaps the active certificate on every member, including
+// the leader, and reloads the HTTPS listener without dropping in-flight connections, reverting any member
+// already swapped if a later one fails so the cluster never stays split across two active certificates; the
+// retired certificate is then kept around for clusterCertificateGraceWindow before being deleted. Only the
+// leader can drive a rotation; non-leaders forward the request, mirroring clusterPost.
+func clusterCertificatePut(state *state.State, r *http.Request) response.Response {
+	ctx, cancel := context.WithTimeout(state.Context, time.Second*30)
+	defer cancel()
+
+	leaderClient, err := state.Database.Leader(ctx)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	leaderInfo, err := leaderClient.Leader(ctx)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if leaderInfo.Address != state.Address.URL.Host {
+		leader, err := state.Leader()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		err = leader.RotateClusterCertificate(ctx)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.EmptySyncResponse
+	}
+
+	newCert, newKey, err := shared.GenerateMemCert(false, true)
+	if err != nil {
+		return response.SmartError(fmt.Errorf("Failed to generate new cluster certificate: %w", err))
+	}
+
+	err = stageClusterCertificate(state, newCert, newKey)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	publicKey, err := state.ClusterCert().PublicKeyX509()
+	if err != nil {
+		rollbackClusterCertificate(state, nil)
+		return response.SmartError(err)
+	}
+
+	pendingCert := types.X509Certificate{Certificate: string(newCert)}
+	members := state.Remotes().RemotesByName()
+
+	// Phase 1: stage the new certificate on every member, next to the current one. acked tracks who
+	// already staged it, so a failure partway through can roll back exactly those members
+	// instead of leaving them trusting a certificate the rotation never finished pushing.
+	var acked []trust.Remote
+	for _, member := range members {
+		if member.Address.String() == state.Address.URL.Host {
+			continue
+		}
+
+		memberClient, err := client.New(member.URL(), state.ServerCert(), publicKey, false)
+		if err != nil {
+			rollbackClusterCertificate(state, acked)
+			return response.SmartError(fmt.Errorf("Failed to contact cluster member %q during certificate rotation: %w", member.Name, err))
+		}
+
+		err = memberClient.SendClusterCertificate(state.Context, pendingCert, string(newKey))
+		if err != nil {
+			rollbackClusterCertificate(state, acked)
+			return response.SmartError(fmt.Errorf("Cluster member %q rejected the new certificate, rotation rolled back: %w", member.Name, err))
+		}
+
+		acked = append(acked, member)
+	}
+
+	// Phase 2: every member now has the new certificate staged, so swap the active one everywhere. activated
+	// tracks who has already swapped, so a failure partway through can revert exactly those members back to
+	// their previous certificate instead of leaving the cluster split across two different active
+	// certificates.
+	var activated []trust.Remote
+	for _, member := range members {
+		if member.Address.String() == state.Address.URL.Host {
+			continue
+		}
+
+		memberClient, err := client.New(member.URL(), state.ServerCert(), publicKey, false)
+		if err != nil {
+			revertActivatedClusterCertificates(state, activated, publicKey)
+			return response.SmartError(fmt.Errorf("Failed to contact cluster member %q to activate the new certificate, rotation rolled back: %w", member.Name, err))
+		}
+
+		err = memberClient.ActivateClusterCertificate(state.Context)
+		if err != nil {
+			revertActivatedClusterCertificates(state, activated, publicKey)
+			return response.SmartError(fmt.Errorf("Cluster member %q failed to activate the new certificate, rotation rolled back: %w", member.Name, err))
+		}
+
+		activated = append(activated, member)
+	}
+
+	err = activateClusterCertificate(state)
+	if err != nil {
+		revertActivatedClusterCertificates(state, activated, publicKey)
+		return response.SmartError(err)
+	}
+
+	// Phase 3: retain the retired certificate for the grace window in case a member missed the swap, then
+	// delete it.
+	time.AfterFunc(clusterCertificateGraceWindow, func() {
+		deleteRetiredClusterCertificate(state)
+	})
+
+	return response.EmptySyncResponse
+}
+
+// clusterCertificateStagePost is the receiving end of SendClusterCertificate: it writes the cluster CA
+// keypair the leader generated for a rotation next to the active one, without activating it.
+func clusterCertificateStagePost(state *state.State, r *http.Request) response.Response {
+	req := struct {
+		ClusterCert types.X509Certificate `json:"cluster_cert"`
+		ClusterKey  string                `json:"cluster_key"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = stageClusterCertificate(state, []byte(req.ClusterCert.String()), []byte(req.ClusterKey))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// clusterCertificateActivatePost is the receiving end of ActivateClusterCertificate: it swaps this member's
+// active cluster certificate to the one previously staged by clusterCertificateStagePost.
+func clusterCertificateActivatePost(state *state.State, r *http.Request) response.Response {
+	err := activateClusterCertificate(state)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// clusterCertificateStageDelete is the receiving end of RollbackClusterCertificate: it discards whatever
+// cluster CA keypair clusterCertificateStagePost previously staged on this member.
+func clusterCertificateStageDelete(state *state.State, r *http.Request) response.Response {
+	rollbackClusterCertificate(state, nil)
+
+	return response.EmptySyncResponse
+}
+
+// clusterCertificateActivateDelete is the receiving end of RevertClusterCertificate: it undoes a previous
+// clusterCertificateActivatePost, swapping this member's active cluster certificate back to the one it had
+// before.
+func clusterCertificateActivateDelete(state *state.State, r *http.Request) response.Response {
+	err := revertClusterCertificateActivation(state)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// stageClusterCertificate writes a newly generated CA keypair next to the active one without activating it.
+func stageClusterCertificate(state *state.State, cert []byte, key []byte) error {
+	err := os.WriteFile(filepath.Join(state.OS.StateDir, "cluster.crt.new"), cert, 0o644)
+	if err != nil {
+		return fmt.Errorf("Failed to write staged cluster certificate: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(state.OS.StateDir, "cluster.key.new"), key, 0o600)
+	if err != nil {
+		return fmt.Errorf("Failed to write staged cluster key: %w", err)
+	}
+
+	return nil
+}
+
+// rollbackClusterCertificate discards a staged certificate rotation that failed before activation, both
+// locally and on every member in acked, which have already staged it during phase 1.
+func rollbackClusterCertificate(state *state.State, acked []trust.Remote) {
+	err := os.RemoveAll(filepath.Join(state.OS.StateDir, "cluster.crt.new"))
+	if err != nil {
+		logger.Warnf("Failed to roll back staged cluster certificate: %v", err)
+	}
+
+	err = os.RemoveAll(filepath.Join(state.OS.StateDir, "cluster.key.new"))
+	if err != nil {
+		logger.Warnf("Failed to roll back staged cluster key: %v", err)
+	}
+
+	if len(acked) == 0 {
+		return
+	}
+
+	publicKey, err := state.ClusterCert().PublicKeyX509()
+	if err != nil {
+		logger.Warnf("Failed to roll back staged cluster certificate on cluster members: %v", err)
+		return
+	}
+
+	for _, member := range acked {
+		memberClient, err := client.New(member.URL(), state.ServerCert(), publicKey, false)
+		if err != nil {
+			logger.Warnf("Failed to contact cluster member %q to roll back the staged certificate: %v", member.Name, err)
+			continue
+		}
+
+		err = memberClient.RollbackClusterCertificate(state.Context)
+		if err != nil {
+			logger.Warnf("Cluster member %q failed to roll back the staged certificate: %v", member.Name, err)
+		}
+	}
+}
+
+// revertActivatedClusterCertificates undoes phase 2 of a rotation that failed partway through activating
+// the new certificate across the cluster: it reverts every member in activated back to the certificate it
+// had before, via RevertClusterCertificate, so the cluster doesn't end up split across two different active
+// certificates. The staged certificate itself is left in place everywhere, including on members that never
+// got to activate it, so a retried rotation can activate the same certificate again instead of starting
+// over from phase 1.
+func revertActivatedClusterCertificates(state *state.State, activated []trust.Remote, publicKey *x509.Certificate) {
+	for _, member := range activated {
+		memberClient, err := client.New(member.URL(), state.ServerCert(), publicKey, false)
+		if err != nil {
+			logger.Warnf("Failed to contact cluster member %q to revert the activated certificate: %v", member.Name, err)
+			continue
+		}
+
+		err = memberClient.RevertClusterCertificate(state.Context)
+		if err != nil {
+			logger.Warnf("Cluster member %q failed to revert the activated certificate: %v", member.Name, err)
+		}
+	}
+}
+
+// activateClusterCertificate swaps the staged certificate pair into place, retains the previous pair under
+// a ".old" suffix as a rollback fallback, and reloads the HTTPS listener so the new certificate takes effect
+// without dropping in-flight connections. If the swap itself succeeds but the reload fails, it undoes the
+// swap before returning the error, so this member is never left activated on disk while
+// revertActivatedClusterCertificates is busy reverting every other member that already activated.
+func activateClusterCertificate(state *state.State) error {
+	err := swapCertificatePair(state.OS.StateDir, "new", "old")
+	if err != nil {
+		return err
+	}
+
+	err = state.ReloadClusterCert()
+	if err != nil {
+		if revertErr := swapCertificatePair(state.OS.StateDir, "old", "new"); revertErr != nil {
+			logger.Errorf("Failed to revert the cluster certificate after a failed reload, the active certificate and the HTTPS listener may now be mismatched: %v", revertErr)
+		}
+
+		return fmt.Errorf("Failed to reload the new cluster certificate: %w", err)
+	}
+
+	return nil
+}
+
+// revertClusterCertificateActivation undoes activateClusterCertificate: it swaps the retired ".old"
+// certificate pair back into place, re-staging the certificate that had been active as ".new" so a retried
+// rotation can activate it again instead of starting over from phase 1, and reloads the HTTPS listener so
+// the reverted certificate takes effect. It is the receiving end of RevertClusterCertificate, which
+// clusterCertificatePut's phase 2 calls on whatever members already activated the new certificate if
+// activation fails partway across the cluster, so the cluster doesn't end up split across two different
+// active certificates.
+func revertClusterCertificateActivation(state *state.State) error {
+	err := swapCertificatePair(state.OS.StateDir, "old", "new")
+	if err != nil {
+		return err
+	}
+
+	return state.ReloadClusterCert()
+}
+
+// swapCertificatePair makes the pair at cluster.crt.<fromSuffix>/cluster.key.<fromSuffix> the active
+// certificate, stashing whatever was previously active as cluster.crt.<toSuffix>/cluster.key.<toSuffix>.
+// activateClusterCertificate calls it with fromSuffix "new", toSuffix "old" to activate a freshly staged
+// certificate; revertClusterCertificateActivation calls it the other way round to undo that.
+//
+// The swap touches two files (certificate and key), which can't be renamed into place in a single syscall,
+// so a failure partway through can't be made truly atomic. It's made safely retryable instead: the
+// currently active pair is copied, not moved, to its toSuffix name first, so the active files are never
+// destroyed before the incoming pair is confirmed in place. If swapping in the incoming pair then fails
+// partway, whatever was already swapped is rolled back to exactly the pre-call state - active pair
+// restored, incoming pair left at fromSuffix - rather than left half-migrated with a mismatched cert/key
+// pair active, so the caller can just retry.
+func swapCertificatePair(stateDir string, fromSuffix string, toSuffix string) error {
+	activeCrt := filepath.Join(stateDir, "cluster.crt")
+	activeKey := filepath.Join(stateDir, "cluster.key")
+	fromCrt := filepath.Join(stateDir, "cluster.crt."+fromSuffix)
+	fromKey := filepath.Join(stateDir, "cluster.key."+fromSuffix)
+	toCrt := filepath.Join(stateDir, "cluster.crt."+toSuffix)
+	toKey := filepath.Join(stateDir, "cluster.key."+toSuffix)
+
+	err := copyFile(activeCrt, toCrt)
+	if err != nil {
+		return fmt.Errorf("Failed to retain the currently active cluster certificate: %w", err)
+	}
+
+	err = copyFile(activeKey, toKey)
+	if err != nil {
+		_ = os.Remove(toCrt)
+		return fmt.Errorf("Failed to retain the currently active cluster key: %w", err)
+	}
+
+	err = os.Rename(fromCrt, activeCrt)
+	if err != nil {
+		_ = os.Remove(toCrt)
+		_ = os.Remove(toKey)
+		return fmt.Errorf("Failed to activate the new cluster certificate: %w", err)
+	}
+
+	err = os.Rename(fromKey, activeKey)
+	if err != nil {
+		// The certificate swapped in but the key didn't; put both back exactly as they were before this
+		// call rather than leave the daemon with a mismatched cert/key pair active.
+		if rollbackErr := os.Rename(activeCrt, fromCrt); rollbackErr != nil {
+			logger.Errorf("Failed to stage the new cluster certificate back during a failed activation, the active certificate and key may now be mismatched: %v", rollbackErr)
+			return fmt.Errorf("Failed to activate the new cluster key: %w", err)
+		}
+
+		if restoreErr := os.Rename(toCrt, activeCrt); restoreErr != nil {
+			logger.Errorf("Failed to restore the previously active cluster certificate after a failed activation, there is no active certificate: %v", restoreErr)
+			return fmt.Errorf("Failed to activate the new cluster key: %w", err)
+		}
+
+		_ = os.Remove(toKey)
+
+		return fmt.Errorf("Failed to activate the new cluster key: %w", err)
+	}
+
+	return nil
+}
+
+// copyFile copies src to dst, preserving src's permissions, creating dst if it doesn't exist and
+// truncating it if it does. Used to stash the currently active certificate pair before swapCertificatePair
+// moves a new one into place, so a failure partway through the copy never touches the original.
+func copyFile(src string, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	err = out.Chmod(info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// deleteRetiredClusterCertificate removes the certificate pair that activateClusterCertificate retired,
+// once it is no longer needed as a rollback fallback.
+func deleteRetiredClusterCertificate(state *state.State) {
+	stateDir := state.OS.StateDir
+
+	err := os.RemoveAll(filepath.Join(stateDir, "cluster.crt.old"))
+	if err != nil {
+		logger.Warnf("Failed to remove retired cluster certificate: %v", err)
+	}
+
+	err = os.RemoveAll(filepath.Join(stateDir, "cluster.key.old"))
+	if err != nil {
+		logger.Warnf("Failed to remove retired cluster key: %v", err)
+	}
+}
+
 // clusterDisableMu is used to prevent the daemon process from being replaced/stopped during removal from the
 // cluster until such time as the request that initiated the removal has finished. This allows for self removal
 // from the cluster when not the leader.
 var clusterDisableMu sync.Mutex
 
-// Re-execs the daemon of the cluster member with a fresh state.
+// clusterMemberPut tears down the local dqlite state and re-execs the daemon with a fresh state, e.g. after
+// being removed from the cluster. The teardown and re-exec run under an Operation, whose fn starts before
+// the 202 response is necessarily written; reExecDaemon waits on the operation's responseSent signal before
+// calling unix.Exec, which is what previously required flushing a partial response by hand.
 func clusterMemberPut(state *state.State, r *http.Request) response.Response {
+	return runOperation(state.Context, func(ctx context.Context, op *Operation) (any, error) {
+		return nil, reExecDaemon(ctx, op, state, false)
+	})
+}
+
+// reExecDaemon stops the database, wipes the state directory, and re-execs the daemon binary with a fresh
+// state. clusterDisableMu is held across the exec; if another operation holds it, the daemon isn't replaced
+// until that operation has finished. lockHeld must be
+// true when the caller already holds clusterDisableMu itself (clusterMemberDeleteRun's self-removal path),
+// since sync.Mutex isn't reentrant and a second Lock from the same goroutine would hang forever.
+func reExecDaemon(ctx context.Context, op *Operation, state *state.State, lockHeld bool) error {
+	op.setStep("Stopping database")
+
 	err := state.Database.Stop()
 	if err != nil {
-		return response.SmartError(fmt.Errorf("Failed shutting down database: %w", err))
+		return fmt.Errorf("Failed shutting down database: %w", err)
 	}
 
 	err = os.RemoveAll(state.OS.StateDir)
 	if err != nil {
-		return response.SmartError(fmt.Errorf("Failed to remove the state directory: %w", err))
+		return fmt.Errorf("Failed to remove the state directory: %w", err)
 	}
 
-	go func() {
-		<-r.Context().Done() // Wait until request has finished.
+	op.setStep("Restarting daemon")
 
-		// Wait until we can acquire the lock. This way if another request is holding the lock we won't
-		// replace/stop the LXD daemon until that request has finished.
+	if !lockHeld {
+		// Wait until we can acquire the lock. This way if another operation is holding the lock we won't
+		// replace/stop the daemon until that operation has finished.
 		clusterDisableMu.Lock()
 		defer clusterDisableMu.Unlock()
-		execPath, err := os.Readlink("/proc/self/exe")
-		if err != nil {
-			execPath = "bad-exec-path"
-		}
+	}
 
-		// The execPath from /proc/self/exe can end with " (deleted)" if the lxd binary has been removed/changed
-		// since the lxd process was started, strip this so that we only return a valid path.
-		logger.Info("Restarting daemon following removal from cluster")
-		execPath = strings.TrimSuffix(execPath, " (deleted)")
-		err = unix.Exec(execPath, os.Args, os.Environ())
-		if err != nil {
-			logger.Error("Failed restarting daemon", logger.Ctx{"err": err})
-		}
-	}()
+	// unix.Exec below replaces this process image entirely, so make sure the 202 response for this
+	// operation has actually been written first. fn (and so reExecDaemon) is started before the framework
+	// necessarily renders runOperation's ManualResponse, so without this wait the response can still be
+	// sitting unflushed when the process underneath it disappears.
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Second*30)
+	err = op.waitForResponseSent(waitCtx)
+	waitCancel()
+	if err != nil {
+		logger.Warnf("Restarting daemon without confirmation the operation response was sent: %v", err)
+	}
 
-	return response.ManualResponse(func(w http.ResponseWriter) error {
-		err := response.EmptySyncResponse.Render(w)
-		if err != nil {
-			return err
-		}
+	execPath, err := os.Readlink("/proc/self/exe")
+	if err != nil {
+		execPath = "bad-exec-path"
+	}
 
-		// Send the response before replacing the LXD daemon process.
-		f, ok := w.(http.Flusher)
-		if ok {
-			f.Flush()
-		} else {
-			return fmt.Errorf("http.ResponseWriter is not type http.Flusher")
-		}
+	// The execPath from /proc/self/exe can end with " (deleted)" if the lxd binary has been removed/changed
+	// since the lxd process was started, strip this so that we only return a valid path.
+	logger.Info("Restarting daemon following removal from cluster")
+	execPath = strings.TrimSuffix(execPath, " (deleted)")
+	err = unix.Exec(execPath, os.Args, os.Environ())
+	if err != nil {
+		logger.Error("Failed restarting daemon", logger.Ctx{"err": err})
+		return err
+	}
 
-		return nil
-	})
+	return nil
 }
 
-// clusterMemberDelete Removes a cluster member from dqlite and re-execs its daemon.
+// clusterMemberDelete removes a cluster member from dqlite. Removal is genuinely unbounded — forwarding to
+// the leader, the dqlite leader.Remove call, and the reset RPC against the removed member can all run long
+// enough to blow past a flat deadline — so it runs under an Operation just like clusterMemberPut, returning
+// 202 Accepted immediately. A PreRemove veto still reaches the caller verbatim: it becomes the Operation's
+// Err, which the client's DeleteClusterMember surfaces as the error Wait returns.
+//
+// If the leader is removing itself, or a non-leader removes itself with force set, the final step re-execs
+// the daemon within the same Operation. A non-forced non-leader self-removal instead forwards to the leader
+// and is done once that call completes — the leader's own ResetClusterMember call back against it is what
+// actually drives its re-exec, via a separate request this handler never waits on.
+//
+// If the force query parameter is set, the removal tolerates the target member being unreachable: the
+// reset RPC against it is skipped and a failure to remove it from dqlite does not abort the request, since
+// its row has already been dropped from the cluster database.
 func clusterMemberDelete(state *state.State, r *http.Request) response.Response {
 	name, err := url.PathUnescape(mux.Vars(r)["name"])
 	if err != nil {
 		return response.SmartError(err)
 	}
 
+	force := r.URL.Query().Get("force") == "1"
+
 	allRemotes := state.Remotes().RemotesByName()
 	remote, ok := allRemotes[name]
 	if !ok {
 		return response.SmartError(fmt.Errorf("No remote exists with the given name %q", name))
 	}
 
-	ctx, cancel := context.WithTimeout(state.Context, time.Second*30)
+	return runOperation(state.Context, func(ctx context.Context, op *Operation) (any, error) {
+		needsReExec, err := clusterMemberDeleteRun(ctx, op, state, name, force, allRemotes, remote)
+		if err != nil {
+			return nil, err
+		}
+
+		if !needsReExec {
+			return nil, nil
+		}
+
+		// clusterMemberDeleteRun left clusterDisableMu locked for us; release it once the re-exec this
+		// triggers has actually happened.
+		defer func() {
+			logger.Info("Releasing cluster self removal lock", logger.Ctx{"member": name})
+			clusterDisableMu.Unlock()
+		}()
+
+		op.setStep("Restarting daemon")
+		return nil, reExecDaemon(ctx, op, state, true)
+	})
+}
+
+// clusterMemberDeleteRun resolves the leader, forwards the request if this node isn't it, and otherwise
+// removes the member from dqlite. It reports whether the caller must now re-exec this same daemon, in which
+// case clusterDisableMu is left locked for the caller to release once the re-exec it triggers has actually
+// run. That's true when this node is the leader and is removing itself, and also when a non-leader removes
+// itself with force set. A non-forced non-leader self-removal instead relies on the leader's separate
+// ResetClusterMember call back against it to drive the re-exec (see below), so it reports false even though
+// it too is going away; force skips that callback, so this reports true and drives the re-exec itself.
+func clusterMemberDeleteRun(ctx context.Context, op *Operation, state *state.State, name string, force bool, allRemotes map[string]trust.Remote, remote trust.Remote) (needsReExec bool, err error) {
+	removingSelf := remote.Address.String() == state.Address.URL.Host
+
+	op.setStep("Finding cluster leader")
+
+	dqliteCtx, cancel := context.WithTimeout(ctx, time.Second*30)
 	defer cancel()
 
-	leader, err := state.Database.Leader(ctx)
+	leader, err := state.Database.Leader(dqliteCtx)
 	if err != nil {
-		return response.SmartError(err)
+		return false, err
 	}
 
-	leaderInfo, err := leader.Leader(ctx)
+	leaderInfo, err := leader.Leader(dqliteCtx)
 	if err != nil {
-		return response.SmartError(err)
+		return false, err
 	}
 
 	// If we are not the leader, just update our trust store.
 	if leaderInfo.Address != state.Address.URL.Host {
-		if allRemotes[name].Address.String() == state.Address.URL.Host {
-			// If the member being removed is ourselves and we are not the leader, then lock the
-			// clusterPutDisableMu before we forward the request to the leader, so that when the leader
-			// goes on to request clusterPutDisable back to ourselves it won't be actioned until we
-			// have returned this request back to the original client.
-			clusterDisableMu.Lock()
-			logger.Info("Acquired cluster self removal lock", logger.Ctx{"member": name})
-
-			go func() {
-				<-r.Context().Done() // Wait until request is finished.
-
-				logger.Info("Releasing cluster self removal lock", logger.Ctx{"member": name})
-				clusterDisableMu.Unlock()
-			}()
-		}
+		op.setStep("Forwarding removal request to the cluster leader")
 
-		client, err := state.Leader()
+		leaderClient, err := state.Leader()
 		if err != nil {
-			return response.SmartError(err)
+			return false, err
 		}
 
-		err = client.DeleteClusterMember(state.Context, name)
+		// Forwarded without holding clusterDisableMu: when removingSelf, the leader's handling of this
+		// request calls ResetClusterMember back against this same node once it removes us from dqlite
+		// (see below), and that PUT needs clusterDisableMu to run its own reExecDaemon. Locking it here
+		// while blocked waiting on the forwarded call would deadlock the two goroutines against each
+		// other until the leader's request times out, reporting failure for a removal that actually
+		// succeeded.
+		err = leaderClient.DeleteClusterMember(ctx, name, force)
 		if err != nil {
-			return response.SmartError(err)
+			return false, err
 		}
 
-		newRemotes := []internalTypes.ClusterMember{}
-		for _, remote := range allRemotes {
-			if remote.Name != name {
-				clusterMember := internalTypes.ClusterMemberLocal{Name: remote.Name, Address: remote.Address, Certificate: remote.Certificate}
-				newRemotes = append(newRemotes, internalTypes.ClusterMember{ClusterMemberLocal: clusterMember})
+		if removingSelf {
+			if !force {
+				// The ResetClusterMember call the leader just made against us drives our own
+				// reExecDaemon independently of this request; there's nothing left for it to do.
+				return false, nil
 			}
-		}
 
-		err = state.Remotes().Replace(state.OS.TrustDir, newRemotes...)
-		if err != nil {
-			return response.SmartError(err)
+			// force skips the leader's ResetClusterMember callback against the removed member (see
+			// below), so nothing else is going to trigger our re-exec; do it ourselves. Safe to lock
+			// clusterDisableMu now, unlike before the forward call above: we're no longer blocked
+			// waiting on a request that could itself be blocked waiting on this same lock.
+			clusterDisableMu.Lock()
+			logger.Info("Acquired cluster self removal lock", logger.Ctx{"member": name})
+
+			return true, nil
 		}
 
-		return response.ManualResponse(func(w http.ResponseWriter) error {
-			err := response.EmptySyncResponse.Render(w)
-			if err != nil {
-				return err
-			}
+		return false, state.Remotes().Replace(state.OS.TrustDir, remotesExcluding(allRemotes, name)...)
+	}
 
-			// Send the response before replacing the LXD daemon process.
-			f, ok := w.(http.Flusher)
-			if ok {
-				f.Flush()
-			} else {
-				return fmt.Errorf("http.ResponseWriter is not type http.Flusher")
-			}
+	// We're the leader. If we're also removing ourselves, hold clusterDisableMu for the rest of this
+	// removal and the re-exec that follows it, so a clusterMemberPut racing in some other way can't
+	// interleave with the teardown below. Nothing forwards back into us here, unlike the branch above, so
+	// this can't deadlock the same way.
+	if removingSelf {
+		clusterDisableMu.Lock()
+		logger.Info("Acquired cluster self removal lock", logger.Ctx{"member": name})
 
-			return nil
-		})
+		defer func() {
+			if err != nil {
+				logger.Info("Releasing cluster self removal lock", logger.Ctx{"member": name})
+				clusterDisableMu.Unlock()
+			}
+		}()
 	}
 
-	info, err := leader.Cluster(state.Context)
+	op.setStep("Removing member from dqlite")
+
+	info, err := leader.Cluster(ctx)
 	if err != nil {
-		return response.SmartError(err)
+		return false, err
 	}
 
 	index := -1
@@ -363,17 +928,17 @@ func clusterMemberDelete(state *state.State, r *http.Request) response.Response
 	}
 
 	if index < 0 {
-		return response.SmartError(fmt.Errorf("No dqlite cluster member exists with the given name %q", name))
+		return false, fmt.Errorf("No dqlite cluster member exists with the given name %q", name)
 	}
 
 	localClient, err := client.New(state.OS.ControlSocket(), nil, nil, false)
 	if err != nil {
-		return response.SmartError(err)
+		return false, err
 	}
 
-	clusterMembers, err := localClient.GetClusterMembers(state.Context)
+	clusterMembers, err := localClient.GetClusterMembers(ctx)
 	if err != nil {
-		return response.SmartError(err)
+		return false, err
 	}
 
 	numPending := 0
@@ -384,73 +949,350 @@ func clusterMemberDelete(state *state.State, r *http.Request) response.Response
 	}
 
 	if len(clusterMembers)-numPending < 2 {
-		return response.SmartError(fmt.Errorf("Cannot remove cluster members, there are no remaining non-pending members"))
+		return false, fmt.Errorf("Cannot remove cluster members, there are no remaining non-pending members")
 	}
 
 	if len(info) < 2 {
-		return response.SmartError(fmt.Errorf("Cannot leave a cluster with %d members", len(info)))
+		return false, fmt.Errorf("Cannot leave a cluster with %d members", len(info))
 	}
 
-	if len(info) == 2 && allRemotes[name].Address.String() == leaderInfo.Address {
+	if len(info) == 2 && remote.Address.String() == leaderInfo.Address {
 		for _, node := range info {
 			if node.Address != leaderInfo.Address {
-				err = leader.Assign(ctx, node.ID, dqliteClient.Voter)
+				err = leader.Assign(dqliteCtx, node.ID, dqliteClient.Voter)
 				if err != nil {
-					return response.SmartError(err)
+					return false, err
 				}
 			}
 		}
 	}
 
 	// Remove the cluster member from the database.
-	err = state.Database.Transaction(state.Context, func(ctx context.Context, tx *db.Tx) error {
+	err = state.Database.Transaction(ctx, func(ctx context.Context, tx *db.Tx) error {
+		// Consumers can veto the removal here, e.g. a node still holding the only replica of some
+		// resource, reading their own schema atomically with the membership change.
+		if state.Hooks.PreRemove != nil {
+			err := state.Hooks.PreRemove(ctx, tx.Tx, name)
+			if err != nil {
+				return err
+			}
+		}
+
 		return cluster.DeleteInternalClusterMember(ctx, tx, info[index].Address)
 	})
 	if err != nil {
-		return response.SmartError(err)
+		return false, err
 	}
 
 	// Remove the node from dqlite.
-	err = leader.Remove(state.Context, info[index].ID)
+	err = leader.Remove(ctx, info[index].ID)
 	if err != nil {
-		return response.SmartError(err)
+		if !force {
+			return false, err
+		}
+
+		// The member's row is already gone from the cluster database, so a dead node that can't
+		// acknowledge its own removal from dqlite shouldn't block the request.
+		logger.Warnf("Failed to remove unreachable cluster member %q from dqlite, continuing because force was requested: %v", name, err)
 	}
 
-	// Reset the state of the removed node.
-	if allRemotes[name].Address.String() == state.Address.URL.Host {
-		return clusterMemberPut(state, r)
-	} else {
+	// Removing a member can leave the cluster short of its target voters/standbys, so converge again. Runs
+	// in the background since it isn't needed to answer the request.
+	triggerRebalance(state, leader)
 
-		newRemotes := []internalTypes.ClusterMember{}
-		for _, remote := range allRemotes {
-			if remote.Name != name {
-				clusterMember := internalTypes.ClusterMemberLocal{Name: remote.Name, Address: remote.Address, Certificate: remote.Certificate}
-				newRemotes = append(newRemotes, internalTypes.ClusterMember{ClusterMemberLocal: clusterMember})
+	// The removal has already been committed, so a PostRemove failure is logged rather than failing the
+	// request; it runs in the background against state.Context, since ctx is cancelled once
+	// clusterMemberDelete returns.
+	if state.Hooks.PostRemove != nil {
+		go func() {
+			err := state.Hooks.PostRemove(state.Context, name)
+			if err != nil {
+				logger.Warnf("PostRemove hook failed for removed cluster member %q: %v", name, err)
 			}
+		}()
+	}
+
+	// The removed member is ourselves, so the caller re-execs us next; clusterDisableMu stays locked
+	// until that happens.
+	if removingSelf {
+		return true, nil
+	}
+
+	newRemotes := remotesExcluding(allRemotes, name)
+
+	// Remove the cluster member from the leader's trust store.
+	err = state.Remotes().Replace(state.OS.TrustDir, newRemotes...)
+	if err != nil {
+		return false, err
+	}
+
+	if !force {
+		op.setStep("Resetting the removed member's daemon")
+
+		publicKey, err := state.ClusterCert().PublicKeyX509()
+		if err != nil {
+			return false, err
 		}
 
-		// Remove the cluster member from the leader's trust store.
-		err = state.Remotes().Replace(state.OS.TrustDir, newRemotes...)
+		memberClient, err := client.New(remote.URL(), state.ServerCert(), publicKey, false)
 		if err != nil {
-			return response.SmartError(err)
+			return false, err
 		}
 
-		remote := allRemotes[name]
-		publicKey, err := state.ClusterCert().PublicKeyX509()
+		err = memberClient.ResetClusterMember(ctx, name)
 		if err != nil {
-			return response.SmartError(err)
+			return false, err
+		}
+	}
+
+	// Tell the surviving members to drop the removed member from their own trust stores too, so they
+	// stop trying to reach a node that may be permanently gone.
+	broadcastTrustStore(state, newRemotes)
+
+	return false, nil
+}
+
+// remotesExcluding returns allRemotes as an internalTypes.ClusterMember slice, leaving out the member
+// called name.
+func remotesExcluding(allRemotes map[string]trust.Remote, name string) []internalTypes.ClusterMember {
+	newRemotes := []internalTypes.ClusterMember{}
+	for _, remote := range allRemotes {
+		if remote.Name != name {
+			clusterMember := internalTypes.ClusterMemberLocal{Name: remote.Name, Address: remote.Address, Certificate: remote.Certificate}
+			newRemotes = append(newRemotes, internalTypes.ClusterMember{ClusterMemberLocal: clusterMember})
+		}
+	}
+
+	return newRemotes
+}
+
+// clusterMembersPut is the receiving end of UpdateClusterMembers: it replaces this member's local trust
+// store with the given members, e.g. after another member is removed from the cluster so the local record
+// reflects it without waiting to learn about the change some other way.
+func clusterMembersPut(state *state.State, r *http.Request) response.Response {
+	var members []internalTypes.ClusterMember
+	err := json.NewDecoder(r.Body).Decode(&members)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = state.Remotes().Replace(state.OS.TrustDir, members...)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// broadcastTrustStore pushes the given trust store to every remaining cluster member so that they update
+// their local record of the cluster without waiting to learn about the change some other way. Failures are
+// logged rather than surfaced since the removal itself has already succeeded on the leader.
+func broadcastTrustStore(state *state.State, members []internalTypes.ClusterMember) {
+	publicKey, err := state.ClusterCert().PublicKeyX509()
+	if err != nil {
+		logger.Warnf("Failed to broadcast trust store update: %v", err)
+		return
+	}
+
+	for _, member := range members {
+		if member.Address.String() == state.Address.URL.Host {
+			continue
 		}
 
-		client, err := client.New(remote.URL(), state.ServerCert(), publicKey, false)
+		memberClient, err := client.New(member.URL(), state.ServerCert(), publicKey, false)
 		if err != nil {
-			return response.SmartError(err)
+			logger.Warnf("Failed to contact cluster member %q to broadcast trust store update: %v", member.Name, err)
+			continue
 		}
 
-		err = client.ResetClusterMember(state.Context, name)
+		err = memberClient.UpdateClusterMembers(state.Context, members)
 		if err != nil {
-			return response.SmartError(err)
+			logger.Warnf("Failed to push updated trust store to cluster member %q: %v", member.Name, err)
 		}
 	}
+}
 
-	return response.EmptySyncResponse
-}
\ No newline at end of file
+// triggerRebalance plans and applies a rebalance in the background so that callers of clusterPost and
+// clusterMemberDelete aren't held up waiting on it. Failures are logged rather than surfaced, since the
+// member add/remove they follow has already succeeded; the next periodic (StartRebalanceLoop) or forced run
+// will retry.
+func triggerRebalance(state *state.State, leader *dqliteClient.Client) {
+	go func() {
+		ctx, cancel := context.WithTimeout(state.Context, time.Second*30)
+		defer cancel()
+
+		plan, err := planRebalance(state, ctx, leader)
+		if err != nil {
+			logger.Warnf("Failed to plan cluster rebalance: %v", err)
+			return
+		}
+
+		if plan.IsEmpty() {
+			return
+		}
+
+		err = cluster.Apply(ctx, leader, plan)
+		if err != nil {
+			logger.Warnf("Failed to apply cluster rebalance: %v", err)
+		}
+	}()
+}
+
+// rebalanceInterval is how often StartRebalanceLoop re-plans and applies a rebalance, healing role drift
+// caused by a crash that a join/remove trigger never observed.
+const rebalanceInterval = time.Minute
+
+// StartRebalanceLoop runs a rebalance against state.Database on a fixed tick, in addition to the
+// triggerRebalance calls that follow a join or removal, so that role drift a crash leaves behind is healed
+// even without a membership change to prompt it. The daemon calls this once at startup; the returned stop
+// function ends the loop.
+func StartRebalanceLoop(state *state.State) (stop func()) {
+	ticker := time.NewTicker(rebalanceInterval)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(state.Context, time.Second*30)
+				leader, err := state.Database.Leader(ctx)
+				if err != nil {
+					logger.Warnf("Failed to find dqlite leader for periodic rebalance: %v", err)
+					cancel()
+					continue
+				}
+
+				triggerRebalance(state, leader)
+				cancel()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// planRebalance classifies the current dqlite members and returns the plan that converges them towards
+// state.RolesPolicy (or cluster.DefaultRolesPolicy, if that's left at the zero value). It does not apply
+// the plan.
+func planRebalance(state *state.State, ctx context.Context, leader *dqliteClient.Client) (cluster.RebalancePlan, error) {
+	leaderInfo, err := leader.Leader(ctx)
+	if err != nil {
+		return cluster.RebalancePlan{}, err
+	}
+
+	members, err := buildRebalanceMembers(state, ctx, leader)
+	if err != nil {
+		return cluster.RebalancePlan{}, err
+	}
+
+	var leaderID uint64
+	for _, member := range members {
+		if member.Address == leaderInfo.Address {
+			leaderID = member.ID
+			break
+		}
+	}
+
+	policy := state.RolesPolicy
+	if policy == (cluster.RolesPolicy{}) {
+		policy = cluster.DefaultRolesPolicy
+	}
+
+	return cluster.Rebalance(leaderID, members, policy), nil
+}
+
+// buildRebalanceMembers combines the live dqlite member list with the heartbeat recorded in the cluster
+// database and a CheckReady probe, producing the liveness-annotated view that cluster.Rebalance needs.
+func buildRebalanceMembers(state *state.State, ctx context.Context, leader *dqliteClient.Client) ([]cluster.RebalanceMember, error) {
+	info, err := leader.Cluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dbMembers []cluster.InternalClusterMember
+	err = state.Database.Transaction(ctx, func(ctx context.Context, tx *db.Tx) error {
+		var err error
+		dbMembers, err = cluster.GetInternalClusterMembers(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeats := make(map[string]time.Time, len(dbMembers))
+	for _, dbMember := range dbMembers {
+		heartbeats[dbMember.Address] = dbMember.Heartbeat
+	}
+
+	clusterCert, err := state.ClusterCert().PublicKeyX509()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]cluster.RebalanceMember, 0, len(info))
+	for _, node := range info {
+		addr := api.NewURL().Scheme("https").Host(node.Address)
+		d, err := client.New(*addr, state.ServerCert(), clusterCert, false)
+
+		online := false
+		if err == nil {
+			online = d.CheckReady(ctx) == nil
+		}
+
+		members = append(members, cluster.RebalanceMember{
+			NodeInfo:  node,
+			Online:    online,
+			Heartbeat: heartbeats[node.Address],
+		})
+	}
+
+	return members, nil
+}
+
+// clusterRebalanceGet returns the role changes that a rebalance run would currently apply, without
+// applying them.
+func clusterRebalanceGet(state *state.State, r *http.Request) response.Response {
+	ctx, cancel := context.WithTimeout(state.Context, time.Second*5)
+	defer cancel()
+
+	leader, err := state.Database.Leader(ctx)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	plan, err := planRebalance(state, ctx, leader)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, plan)
+}
+
+// clusterRebalancePost forces an immediate rebalance run and applies the resulting plan, via the same
+// planRebalance/cluster.Apply path that StartRebalanceLoop's periodic tick and the post-join/post-remove
+// triggers use.
+func clusterRebalancePost(state *state.State, r *http.Request) response.Response {
+	ctx, cancel := context.WithTimeout(state.Context, time.Second*30)
+	defer cancel()
+
+	leader, err := state.Database.Leader(ctx)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	plan, err := planRebalance(state, ctx, leader)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = cluster.Apply(ctx, leader, plan)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, plan)
+}
@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/canonical/microcluster/internal/trust"
+)
+
+func TestRemotesExcludingOmitsNamedMember(t *testing.T) {
+	allRemotes := map[string]trust.Remote{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+		"c": {Name: "c"},
+	}
+
+	got := remotesExcluding(allRemotes, "b")
+
+	names := make([]string, 0, len(got))
+	for _, member := range got {
+		names = append(names, member.Name)
+	}
+
+	sort.Strings(names)
+
+	if len(names) != 2 || names[0] != "a" || names[1] != "c" {
+		t.Fatalf("expected remaining members [a c], got %v", names)
+	}
+}
+
+func TestRemotesExcludingKeepsAllWhenNameNotFound(t *testing.T) {
+	allRemotes := map[string]trust.Remote{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+	}
+
+	got := remotesExcluding(allRemotes, "nonexistent")
+
+	if len(got) != 2 {
+		t.Fatalf("expected both members kept, got %d: %+v", len(got), got)
+	}
+}
+
+func TestRemotesExcludingEmptyInput(t *testing.T) {
+	got := remotesExcluding(map[string]trust.Remote{}, "a")
+
+	if len(got) != 0 {
+		t.Fatalf("expected no members, got %+v", got)
+	}
+}
+
+func TestPreJoinVetoErrorUnwrapsToTheHookError(t *testing.T) {
+	hookErr := errors.New("node holds 1 unreplicated OSD")
+	veto := error(&preJoinVetoError{err: hookErr})
+
+	if veto.Error() != hookErr.Error() {
+		t.Fatalf("expected the veto's message to match the hook error verbatim, got %q", veto.Error())
+	}
+
+	var got *preJoinVetoError
+	if !errors.As(veto, &got) || got.err != hookErr {
+		t.Fatalf("expected errors.As to recover the preJoinVetoError wrapping the hook error, got %+v", got)
+	}
+
+	if !errors.Is(veto, hookErr) {
+		t.Fatalf("expected errors.Is to see through the wrapper to the hook error")
+	}
+}
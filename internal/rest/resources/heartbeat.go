@@ -0,0 +1,61 @@
+package resources
+
+import (
+	"context"
+	"time"
+
+	"github.com/lxc/lxd/shared/logger"
+
+	"github.com/canonical/microcluster/cluster"
+	"github.com/canonical/microcluster/internal/db"
+	"github.com/canonical/microcluster/internal/state"
+)
+
+// recordHeartbeat stamps this member's own row with the current time and runs the OnHeartbeat hook, if one
+// is registered, in the same transaction so a consumer can keep its own liveness bookkeeping consistent
+// with ours.
+func recordHeartbeat(ctx context.Context, state *state.State) error {
+	return state.Database.Transaction(ctx, func(ctx context.Context, tx *db.Tx) error {
+		err := cluster.UpdateInternalClusterMemberHeartbeat(ctx, tx, state.Address.URL.Host, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if state.Hooks.OnHeartbeat == nil {
+			return nil
+		}
+
+		return state.Hooks.OnHeartbeat(ctx, state.Address.URL.Host)
+	})
+}
+
+// heartbeatInterval is how often StartHeartbeatLoop records this member's own heartbeat.
+const heartbeatInterval = 10 * time.Second
+
+// StartHeartbeatLoop records this member's own heartbeat on a fixed tick via recordHeartbeat, which is what
+// actually makes state.Hooks.OnHeartbeat run; without a caller ticking it, recordHeartbeat was otherwise
+// dead code. The daemon calls this once at startup; the returned stop function ends the loop.
+func StartHeartbeatLoop(state *state.State) (stop func()) {
+	ticker := time.NewTicker(heartbeatInterval)
+	done := make(chan struct{})
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(state.Context, time.Second*5)
+				err := recordHeartbeat(ctx, state)
+				cancel()
+				if err != nil {
+					logger.Warnf("Failed to record heartbeat: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,236 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/lxc/lxd/lxd/response"
+
+	"github.com/canonical/microcluster/internal/rest/access"
+	"github.com/canonical/microcluster/internal/state"
+	"github.com/canonical/microcluster/rest"
+)
+
+var operationsCmd = rest.Endpoint{
+	Path: "operations",
+
+	Get: rest.EndpointAction{Handler: operationsGet, AccessHandler: access.AllowAuthenticated},
+}
+
+var operationCmd = rest.Endpoint{
+	Path: "operations/{uuid}",
+
+	Get:    rest.EndpointAction{Handler: operationGet, AccessHandler: access.AllowAuthenticated},
+	Delete: rest.EndpointAction{Handler: operationDelete, AccessHandler: access.AllowAuthenticated},
+}
+
+// OperationStatus is the lifecycle stage of an Operation.
+type OperationStatus string
+
+const (
+	// OperationRunning is set while an Operation's function is still executing.
+	OperationRunning OperationStatus = "Running"
+
+	// OperationSuccess is set once an Operation's function has returned without error.
+	OperationSuccess OperationStatus = "Success"
+
+	// OperationFailure is set once an Operation's function has returned an error.
+	OperationFailure OperationStatus = "Failure"
+
+	// OperationCancelled is set when an Operation is deleted before its function returns.
+	OperationCancelled OperationStatus = "Cancelled"
+)
+
+// Operation tracks a long-running cluster mutation (join, leave, re-exec) so that the caller gets a
+// 202 Accepted with a Location header back immediately instead of blocking on a short-lived context that
+// a slow or unresponsive member can easily exceed.
+type Operation struct {
+	ID        string
+	Status    OperationStatus
+	Step      string
+	Err       string `json:"Err,omitempty"`
+	Result    any    `json:"Result,omitempty"`
+	CreatedAt time.Time
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+
+	// responseSent is closed once the 202 Accepted response for this operation has actually been written.
+	// Callers about to do something irreversible, like re-exec'ing the daemon, must wait on it first: fn
+	// starts running before the framework has necessarily rendered the ManualResponse runOperation returns.
+	responseSent chan struct{}
+}
+
+var operationsMu sync.Mutex
+var operations = map[string]*Operation{}
+
+// operationRetention is how long a finished or cancelled operation is kept in the operations map for
+// pollers to fetch its final status, before being evicted so that a long-running daemon doesn't accumulate
+// one entry per join/leave/re-exec forever.
+const operationRetention = 5 * time.Minute
+
+// scheduleOperationEviction removes id from the operations map once operationRetention has passed.
+func scheduleOperationEviction(id string) {
+	time.AfterFunc(operationRetention, func() {
+		operationsMu.Lock()
+		delete(operations, id)
+		operationsMu.Unlock()
+	})
+}
+
+// newOperation registers an Operation and returns it along with a context that is cancelled either when the
+// operation finishes or when it is deleted (cancelled) before that happens.
+func newOperation(parent context.Context) (*Operation, context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+
+	op := &Operation{
+		ID:           uuid.New().String(),
+		Status:       OperationRunning,
+		CreatedAt:    time.Now(),
+		cancel:       cancel,
+		responseSent: make(chan struct{}),
+	}
+
+	operationsMu.Lock()
+	operations[op.ID] = op
+	operationsMu.Unlock()
+
+	return op, ctx
+}
+
+// setStep records what the operation is currently doing, surfaced to pollers via GET.
+func (o *Operation) setStep(step string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Step = step
+}
+
+// finish records the final result of the operation, releases its context, and schedules its eventual
+// eviction from the operations map.
+func (o *Operation) finish(result any, err error) {
+	o.mu.Lock()
+
+	if o.Status != OperationRunning {
+		// Already cancelled.
+		o.mu.Unlock()
+		return
+	}
+
+	if err != nil {
+		o.Status = OperationFailure
+		o.Err = err.Error()
+	} else {
+		o.Status = OperationSuccess
+		o.Result = result
+	}
+
+	o.mu.Unlock()
+
+	o.cancel()
+	scheduleOperationEviction(o.ID)
+}
+
+// waitForResponseSent blocks until this operation's 202 response has been written, or ctx is done,
+// whichever happens first.
+func (o *Operation) waitForResponseSent(ctx context.Context) error {
+	select {
+	case <-o.responseSent:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// snapshot returns a copy of the operation's fields safe to serialise without holding its lock.
+func (o *Operation) snapshot() Operation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return Operation{ID: o.ID, Status: o.Status, Step: o.Step, Err: o.Err, Result: o.Result, CreatedAt: o.CreatedAt}
+}
+
+// runOperation starts fn in the background against a new Operation and immediately returns a 202 Accepted
+// response whose Location header points at /1.0/operations/{uuid}. Callers can poll that URL, or use the Go
+// client's Operation.Wait to block for structured completion instead of an opaque request timeout.
+func runOperation(parent context.Context, fn func(ctx context.Context, op *Operation) (any, error)) response.Response {
+	op, ctx := newOperation(parent)
+
+	go func() {
+		result, err := fn(ctx, op)
+		op.finish(result, err)
+	}()
+
+	return response.ManualResponse(func(w http.ResponseWriter) error {
+		defer close(op.responseSent)
+
+		w.Header().Set("Location", fmt.Sprintf("/1.0/operations/%s", op.ID))
+		w.WriteHeader(http.StatusAccepted)
+
+		err := json.NewEncoder(w).Encode(op.snapshot())
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+
+		return err
+	})
+}
+
+// operationsGet lists all operations this member currently knows about.
+func operationsGet(state *state.State, r *http.Request) response.Response {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+
+	result := make([]Operation, 0, len(operations))
+	for _, op := range operations {
+		result = append(result, op.snapshot())
+	}
+
+	return response.SyncResponse(true, result)
+}
+
+// operationGet returns the current state of a single operation.
+func operationGet(state *state.State, r *http.Request) response.Response {
+	id := mux.Vars(r)["uuid"]
+
+	operationsMu.Lock()
+	op, ok := operations[id]
+	operationsMu.Unlock()
+	if !ok {
+		return response.NotFound(fmt.Errorf("No operation exists with ID %q", id))
+	}
+
+	return response.SyncResponse(true, op.snapshot())
+}
+
+// operationDelete cancels a running operation. It has no effect on one that has already finished.
+func operationDelete(state *state.State, r *http.Request) response.Response {
+	id := mux.Vars(r)["uuid"]
+
+	operationsMu.Lock()
+	op, ok := operations[id]
+	operationsMu.Unlock()
+	if !ok {
+		return response.NotFound(fmt.Errorf("No operation exists with ID %q", id))
+	}
+
+	op.mu.Lock()
+	cancelled := op.Status == OperationRunning
+	if cancelled {
+		op.Status = OperationCancelled
+		op.cancel()
+	}
+	op.mu.Unlock()
+
+	if cancelled {
+		scheduleOperationEviction(op.ID)
+	}
+
+	return response.EmptySyncResponse
+}
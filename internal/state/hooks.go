@@ -0,0 +1,41 @@
+package state
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/canonical/microcluster/rest/types"
+)
+
+// Hooks holds optional callbacks that a consumer of microcluster (MicroCeph, MicroOVN, ...) can register to
+// run its own checks or cleanup around cluster membership changes and heartbeats. A nil callback is a
+// no-op. Every callback is defined entirely in terms of the standard library and microcluster's public
+// rest/types package, so a consumer module can implement one without importing anything under
+// microcluster/internal, which it couldn't do at all: Go forbids importing another module's internal
+// packages.
+type Hooks struct {
+	// PreJoin runs on the leader, in the same transaction that inserts the new member's row, before it is
+	// inserted. tx is that same transaction, so the hook can read or seed consumer schema tables atomically
+	// with the membership change. Returning an error aborts the join, rolling back the transaction, and is
+	// reported back to the caller.
+	PreJoin func(ctx context.Context, tx *sql.Tx, newMember types.ClusterMember) error
+
+	// PostJoin runs after a join has been committed. Its error is logged rather than surfaced, since the
+	// join itself has already succeeded.
+	PostJoin func(ctx context.Context, newMember types.ClusterMember) error
+
+	// PreRemove runs on the leader, in the same transaction that deletes the member's row, before it is
+	// deleted. tx is that same transaction, so the hook can read or clean up consumer schema tables
+	// atomically with the membership change. Returning an error aborts the removal, rolling back the
+	// transaction, and is reported back to the caller verbatim.
+	PreRemove func(ctx context.Context, tx *sql.Tx, name string) error
+
+	// PostRemove runs after a removal has been committed. Its error is logged rather than surfaced, since
+	// the removal itself has already succeeded.
+	PostRemove func(ctx context.Context, name string) error
+
+	// OnHeartbeat runs in the same transaction that records this member's own heartbeat, and is passed this
+	// member's address. Returning an error rolls that transaction back, so the heartbeat is not recorded;
+	// the error is logged rather than surfaced.
+	OnHeartbeat func(ctx context.Context, address string) error
+}
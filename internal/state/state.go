@@ -0,0 +1,56 @@
+package state
+
+import (
+	"context"
+
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+
+	"github.com/canonical/microcluster/cluster"
+	"github.com/canonical/microcluster/internal/db"
+	"github.com/canonical/microcluster/internal/rest/client"
+	"github.com/canonical/microcluster/internal/sys"
+	"github.com/canonical/microcluster/internal/trust"
+)
+
+// State is the gateway the REST handlers and the daemon use to reach the stateful pieces of a running
+// microcluster instance: its on-disk layout, the dqlite database, the trust store, its certificates, and
+// the hooks a consumer has registered.
+type State struct {
+	// Context is cancelled when the daemon is shutting down.
+	Context context.Context
+
+	// OS groups the directories and sockets that make up this member's on-disk state.
+	OS *sys.OS
+
+	// Address is this member's own listen address.
+	Address api.URL
+
+	// Database is the dqlite-backed cluster database.
+	Database *db.DB
+
+	// Remotes returns the trust store of cluster members this node currently knows about.
+	Remotes func() *trust.Remotes
+
+	// ServerCert returns the certificate this member uses for server-to-server connections.
+	ServerCert func() *shared.CertInfo
+
+	// ClusterCert returns the certificate this member uses for intra-cluster connections.
+	ClusterCert func() *shared.CertInfo
+
+	// ReloadClusterCert re-reads the cluster certificate pair from OS.StateDir and swaps it into ClusterCert,
+	// reloading the HTTPS listener so the new certificate takes effect without dropping in-flight
+	// connections. Called once activateClusterCertificate has swapped the pair into place on disk.
+	ReloadClusterCert func() error
+
+	// Leader returns a client connected to the current cluster leader.
+	Leader func() (*client.Client, error)
+
+	// Hooks holds the optional callbacks a consumer of microcluster (MicroCeph, MicroOVN, ...) has
+	// registered around cluster membership changes and heartbeats.
+	Hooks Hooks
+
+	// RolesPolicy is the target voter/standby shape the rebalance loop converges this cluster towards. The
+	// zero value means cluster.DefaultRolesPolicy.
+	RolesPolicy cluster.RolesPolicy
+}
@@ -0,0 +1,66 @@
+package microcluster
+
+import (
+	"context"
+
+	"github.com/canonical/microcluster/cluster"
+	"github.com/canonical/microcluster/internal/rest/resources"
+	"github.com/canonical/microcluster/internal/state"
+)
+
+// Hooks holds optional callbacks a consumer registers at construction time to observe or veto cluster
+// membership changes and heartbeats. Every callback is defined in terms of the standard library and
+// microcluster's public rest/types package, so a consumer module can implement one directly. See
+// state.Hooks for the individual callbacks.
+type Hooks = state.Hooks
+
+// RolesPolicy is the target dqlite voter/standby shape that the rebalance loop converges the cluster
+// towards. See cluster.RolesPolicy for the individual fields.
+type RolesPolicy = cluster.RolesPolicy
+
+// MicroCluster is the main handle applications use to run and interact with a microcluster daemon.
+type MicroCluster struct {
+	state *state.State
+
+	stopRebalanceLoop func()
+	stopHeartbeatLoop func()
+}
+
+// New returns a MicroCluster bound to state, with hooks registered against it so that the join, removal,
+// and heartbeat paths can call into the consumer as they run. It also starts the background
+// rebalance and heartbeat loops that heal role drift and keep liveness bookkeeping current between
+// membership changes.
+//
+// policy overrides the target voter/standby counts that the rebalance loop converges towards for this
+// MicroCluster; the zero value leaves cluster.DefaultRolesPolicy in place. It is stored on state, not in a
+// package-level variable, so constructing more than one MicroCluster in the same process (e.g. several
+// members in one test binary) gives each its own policy rather than having the last New call's policy win
+// for all of them.
+func New(state *state.State, hooks Hooks, policy RolesPolicy) *MicroCluster {
+	state.Hooks = hooks
+	state.RolesPolicy = policy
+
+	return &MicroCluster{
+		state:             state,
+		stopRebalanceLoop: resources.StartRebalanceLoop(state),
+		stopHeartbeatLoop: resources.StartHeartbeatLoop(state),
+	}
+}
+
+// Stop ends the background rebalance and heartbeat loops started by New.
+func (m *MicroCluster) Stop() {
+	m.stopRebalanceLoop()
+	m.stopHeartbeatLoop()
+}
+
+// RemoveClusterMember removes the cluster member called name. If force is true, the removal tolerates the
+// member being unreachable: the reset RPC against it is skipped and a failure to remove it from dqlite does
+// not abort the request.
+func (m *MicroCluster) RemoveClusterMember(ctx context.Context, name string, force bool) error {
+	c, err := m.state.Leader()
+	if err != nil {
+		return err
+	}
+
+	return c.DeleteClusterMember(ctx, name, force)
+}
@@ -0,0 +1,11 @@
+package types
+
+// ClusterMember is the public view of a cluster member that microcluster hands to a consumer's
+// PreJoin/PostJoin hooks (see state.Hooks). Every field is a plain string, unlike the wire ClusterMember
+// microcluster uses internally, so a hook implementation living in a separate module can depend on it
+// without importing anything under microcluster/internal.
+type ClusterMember struct {
+	Name        string
+	Address     string
+	Certificate string
+}